@@ -0,0 +1,106 @@
+package lexer
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+)
+
+// Error is raised by a Lexer to report a problem bound to a source Position.
+// The top-level participle package normally recovers and re-wraps these
+// panics at the parser level, but this lexer-only checkout does not include
+// that package.
+//
+// All of this package's Lexer implementations report errors by panicking
+// with an *Error, rather than returning one directly, so that deeply nested
+// helpers (e.g. lexer.L's Errorf) don't need to thread an error value back
+// up through every caller.
+type Error struct {
+	Msg string
+	Pos Position
+	src []byte // optional, set by LexWithSource so Pretty needs no argument.
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("%s: %s", e.Pos, e.Msg)
+}
+
+// Pretty renders a caret-underlined, single-line snippet of src around
+// e.Pos, similar to the diagnostics produced by modern compilers, e.g.:
+//
+//	example.txt:3:8: unexpected token "}"
+//	  3 | foo bar}
+//	    |        ^
+//
+// If src is nil, the source remembered by LexWithSource is used instead; if
+// neither is available, or e.Pos has no line information, Pretty falls back
+// to e.Error().
+func (e *Error) Pretty(src []byte) string {
+	if src == nil {
+		src = e.src
+	}
+	if src == nil || e.Pos.Line <= 0 {
+		return e.Error()
+	}
+	lines := bytes.Split(src, []byte("\n"))
+	if e.Pos.Line > len(lines) {
+		return e.Error()
+	}
+	line := string(lines[e.Pos.Line-1])
+	gutter := fmt.Sprintf("%d", e.Pos.Line)
+	pad := strings.Repeat(" ", len(gutter))
+	col := e.Pos.Column
+	if col < 1 {
+		col = 1
+	}
+	caret := strings.Repeat(" ", col-1) + "^"
+	return fmt.Sprintf("%s\n  %s | %s\n  %s | %s", e.Error(), gutter, line, pad, caret)
+}
+
+// Panic raises a lexing error at pos. This is the mechanism by which Lexer
+// implementations in this package report errors.
+func Panic(pos Position, message string) {
+	panic(&Error{Msg: message, Pos: pos})
+}
+
+// Errorf is like Panic but accepts a format string.
+func Errorf(pos Position, format string, args ...interface{}) {
+	panic(&Error{Msg: fmt.Sprintf(format, args...), Pos: pos})
+}
+
+// sourceLexer wraps a Lexer, binding its source so that any *Error it
+// panics with can be pretty-printed via Error.Pretty without the caller
+// having to re-read the file.
+type sourceLexer struct {
+	Lexer
+	src []byte
+}
+
+func (s *sourceLexer) Peek() (t Token) {
+	defer s.bind()
+	return s.Lexer.Peek()
+}
+
+func (s *sourceLexer) Next() (t Token) {
+	defer s.bind()
+	return s.Lexer.Next()
+}
+
+// bind attaches s.src to an in-flight *Error panic, then re-panics.
+func (s *sourceLexer) bind() {
+	r := recover()
+	if r == nil {
+		return
+	}
+	if e, ok := r.(*Error); ok && e.src == nil {
+		e.src = s.src
+	}
+	panic(r)
+}
+
+// LexWithSource returns a Lexer over src, remembering src so that any Error
+// produced while lexing can be pretty-printed via Error.Pretty without the
+// caller needing to re-read the file.
+func LexWithSource(name string, src []byte) Lexer {
+	return &sourceLexer{Lexer: Lex(bytes.NewReader(src), WithFilename(name)), src: src}
+}
@@ -13,6 +13,7 @@
 package lexer
 
 import (
+	"bufio"
 	"bytes"
 	"fmt"
 	"io"
@@ -111,33 +112,139 @@ func (d *defaultDefinition) Symbols() map[string]rune {
 	}
 }
 
-// textScannerLexer is a Lexer based on text/scanner.Scanner
+// IsTrivia implements TriviaLexer: comments are elided from the regular
+// token stream, like Skip rules in the regexp lexer, but remain available
+// through LexerWithTrivia.Trivia.
+func (d *defaultDefinition) IsTrivia(typ rune) bool {
+	return typ == scanner.Comment
+}
+
+// textScannerLexer is a Lexer based on text/scanner.Scanner. It also
+// implements LexerWithTrivia, making comments recognised by text/scanner
+// available via Trivia instead of forcing every grammar to mention them.
 type textScannerLexer struct {
 	scanner  scanner.Scanner
 	peek     *Token
 	filename string
+	reader   io.Reader
+	bom      BOMMode
+	started  bool
+	injected *Token
+	trivia   []Token
+	pending  []Token
 }
 
 type namedReader interface {
 	Name() string
 }
 
+// BOMMode determines how a leading UTF-8 byte order mark is treated by Lex,
+// mirroring the four modes used by golex.
+type BOMMode int
+
+const (
+	// BOMIgnoreFirst silently skips a BOM at the very start of input. A BOM
+	// found anywhere else is an error. This is the default.
+	BOMIgnoreFirst BOMMode = iota
+	// BOMError treats a BOM anywhere in the input, including at the start,
+	// as an error.
+	BOMError
+	// BOMPassFirst leaves a BOM at the start of input in the token stream
+	// untouched. A BOM found anywhere else is an error.
+	BOMPassFirst
+	// BOMPass leaves any BOM, wherever it occurs, in the token stream.
+	BOMPass
+)
+
+// An Option configures the behaviour of Lex.
+type Option func(*lexOptions)
+
+type lexOptions struct {
+	bom      BOMMode
+	filename string
+}
+
+// WithBOM sets how Lex treats a UTF-8 byte order mark. The default is
+// BOMIgnoreFirst.
+func WithBOM(mode BOMMode) Option {
+	return func(o *lexOptions) { o.bom = mode }
+}
+
+// WithFilename overrides the filename reported in token positions and
+// errors. If not given, Lex falls back to r.Name() when r implements
+// interface{ Name() string }, as *os.File does.
+func WithFilename(filename string) Option {
+	return func(o *lexOptions) { o.filename = filename }
+}
+
+// bomRune is the rune text/scanner reports a UTF-8 byte order mark as; it
+// doesn't fall into any of scanner's built-in token classes, so it's
+// returned as its own single-character token.
+const bomRune = '\uFEFF'
+
+const bomSequence = "\xef\xbb\xbf"
+
 // Lex an io.Reader with text/scanner.Scanner.
 //
 // Note that this differs from text/scanner.Scanner in that string tokens will be unquoted.
-func Lex(r io.Reader) Lexer {
-	lexer := &textScannerLexer{}
-	if n, ok := r.(namedReader); ok {
-		lexer.filename = n.Name()
+//
+// A leading byte order mark is handled according to the supplied WithBOM option (BOMIgnoreFirst by
+// default); invalid encodings and misplaced BOMs are reported as a lexer.Error with an accurate
+// Position rather than being silently substituted with unicode.ReplacementChar by text/scanner.
+// Nothing is read from r until the first call to Peek or Next, so these errors surface lazily rather
+// than when Lex is called.
+func Lex(r io.Reader, options ...Option) Lexer {
+	opts := lexOptions{bom: BOMIgnoreFirst}
+	for _, option := range options {
+		option(&opts)
+	}
+	lexer := &textScannerLexer{filename: opts.filename, reader: r, bom: opts.bom}
+	if lexer.filename == "" {
+		if n, ok := r.(namedReader); ok {
+			lexer.filename = n.Name()
+		}
+	}
+	return lexer
+}
+
+// init wires up the underlying scanner on first use, handling a leading BOM
+// per t.bom. It is deferred until the first Peek/Next so that Lex itself
+// never touches r.
+func (t *textScannerLexer) init() {
+	if t.started {
+		return
 	}
-	lexer.scanner.Init(r)
-	lexer.scanner.Error = func(s *scanner.Scanner, msg string) {
+	t.started = true
+	br := bufio.NewReader(t.reader)
+	if peeked, err := br.Peek(len(bomSequence)); err == nil && string(peeked) == bomSequence {
+		switch t.bom {
+		case BOMError:
+			Panic(Position{Filename: t.filename, Line: 1, Column: 1}, "unexpected byte order mark")
+		case BOMIgnoreFirst:
+			_, _ = br.Discard(len(bomSequence))
+		case BOMPassFirst, BOMPass:
+			// text/scanner.Scanner's own Peek has a built-in special case that
+			// silently swallows a leading bomRune, so the scanner would never
+			// report it as a token. Strip the bytes ourselves and synthesize
+			// the token so it still reaches the stream.
+			_, _ = br.Discard(len(bomSequence))
+			t.injected = &Token{
+				Type:  bomRune,
+				Value: "\uFEFF",
+				Pos:   Position{Filename: t.filename, Line: 1, Column: 1},
+			}
+		}
+	}
+	t.scanner.Init(br)
+	t.scanner.Filename = t.filename
+	// Keep comments in the scanner's output so Peek can route them to Trivia.
+	t.scanner.Mode &^= scanner.SkipComments
+	t.scanner.Error = func(s *scanner.Scanner, msg string) {
 		// This is to support single quoted strings. Hacky.
 		if msg != "illegal char literal" {
-			Panic(Position(lexer.scanner.Pos()), msg)
+			Panic(Position(s.Pos()), msg)
 		}
 	}
-	return lexer
 }
 
 // LexString returns a new default lexer over bytes.
@@ -156,39 +263,60 @@ func (t *textScannerLexer) Next() Token {
 	}
 	token := t.peek
 	t.peek = nil
+	t.trivia = t.pending
+	t.pending = nil
 	return *token
 }
 
+// Trivia implements LexerWithTrivia, returning the comments consumed
+// immediately before the token last returned by Next.
+func (t *textScannerLexer) Trivia() []Token {
+	return t.trivia
+}
+
 func (t *textScannerLexer) Peek() Token {
-	if t.peek != nil {
-		return *t.peek
-	}
-	pos := Position(t.scanner.Pos())
-	pos.Filename = t.filename
-	t.peek = &Token{
-		Type:  t.scanner.Scan(),
-		Value: t.scanner.TokenText(),
-		Pos:   pos,
+	t.init()
+	if t.injected != nil {
+		tok := *t.injected
+		t.injected = nil
+		t.peek = &tok
+		return tok
 	}
-	t.peek.Pos.Filename = t.filename
-	// Unquote strings.
-	switch t.peek.Type {
-	case scanner.Char:
-		// FIXME(alec): This is pretty hacky...we convert a single quoted char into a double
-		// quoted string in order to support single quoted strings.
-		t.peek.Value = fmt.Sprintf("\"%s\"", t.peek.Value[1:len(t.peek.Value)-1])
-		fallthrough
-	case scanner.String:
-		s, err := strconv.Unquote(t.peek.Value)
-		if err != nil {
-			Panic(t.peek.Pos, err.Error())
+	for t.peek == nil {
+		pos := Position(t.scanner.Pos())
+		pos.Filename = t.filename
+		typ := t.scanner.Scan()
+		if typ == bomRune && t.bom != BOMPass {
+			Panic(Position(t.scanner.Position), "unexpected byte order mark")
+		}
+		tok := Token{
+			Type:  typ,
+			Value: t.scanner.TokenText(),
+			Pos:   pos,
 		}
-		t.peek.Value = s
-		if t.peek.Type == scanner.Char && utf8.RuneCountInString(s) > 1 {
-			t.peek.Type = scanner.String
+		// Unquote strings.
+		switch tok.Type {
+		case scanner.Char:
+			// FIXME(alec): This is pretty hacky...we convert a single quoted char into a double
+			// quoted string in order to support single quoted strings.
+			tok.Value = fmt.Sprintf("\"%s\"", tok.Value[1:len(tok.Value)-1])
+			fallthrough
+		case scanner.String:
+			s, err := strconv.Unquote(tok.Value)
+			if err != nil {
+				Panic(tok.Pos, err.Error())
+			}
+			tok.Value = s
+			if tok.Type == scanner.Char && utf8.RuneCountInString(s) > 1 {
+				tok.Type = scanner.String
+			}
+		case scanner.RawString:
+			tok.Value = tok.Value[1 : len(tok.Value)-1]
+		case scanner.Comment:
+			t.pending = append(t.pending, tok)
+			continue
 		}
-	case scanner.RawString:
-		t.peek.Value = t.peek.Value[1 : len(t.peek.Value)-1]
+		t.peek = &tok
 	}
 	return *t.peek
 }
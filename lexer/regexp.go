@@ -0,0 +1,231 @@
+package lexer
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+)
+
+// A Rule is a single named regular expression matched by the regexp lexer
+// built with NewRegexpLexer.
+//
+// Rules are tried in declaration order against the current input position;
+// the rule producing the longest match wins, with ties broken in favour of
+// the rule declared earliest. A Pattern beginning with "^" only matches at
+// the start of a line (column 1), which is useful for indentation-sensitive
+// or directive-style tokens.
+type Rule struct {
+	// Name of the token type produced by this rule, exposed through
+	// Definition.Symbols().
+	Name string
+	// Pattern is the regular expression matched against the remaining
+	// input. It is always matched anchored to the current position.
+	Pattern string
+	// Skip, if true, causes tokens matched by this rule to be consumed but
+	// elided from the resulting token stream, e.g. whitespace and comments.
+	Skip bool
+	// Trivia, if true, causes tokens matched by this rule to be elided from
+	// the regular token stream like Skip, but retained and made available
+	// through LexerWithTrivia.Trivia, e.g. for doc comments.
+	Trivia bool
+}
+
+// Rules is an ordered list of named regular expressions. It is the input to
+// NewRegexpLexer.
+type Rules []Rule
+
+type regexpRule struct {
+	Rule
+	typ         rune
+	re          *regexp.Regexp
+	atLineStart bool
+}
+
+// regexpDefinition is a Definition driven by an ordered table of named
+// regular expressions, compiled once and matched with "longest match wins"
+// semantics rather than text/scanner's fixed token classes.
+type regexpDefinition struct {
+	rules   []regexpRule
+	symbols map[string]rune
+}
+
+// NewRegexpLexer constructs a Definition from an ordered list of named
+// regular expression Rules. It returns an error if a rule's pattern fails to
+// compile or a rule name is duplicated.
+func NewRegexpLexer(rules Rules) (Definition, error) {
+	def := &regexpDefinition{
+		symbols: map[string]rune{"EOF": EOF},
+	}
+	nextType := rune(-2)
+	for _, rule := range rules {
+		if _, ok := def.symbols[rule.Name]; ok {
+			return nil, fmt.Errorf("lexer: duplicate rule name %q", rule.Name)
+		}
+		pattern := rule.Pattern
+		atLineStart := strings.HasPrefix(pattern, "^")
+		if atLineStart {
+			pattern = pattern[1:]
+		}
+		re, err := regexp.Compile(`\A(?:` + pattern + `)`)
+		if err != nil {
+			return nil, fmt.Errorf("lexer: rule %q: %s", rule.Name, err)
+		}
+		typ := nextType
+		nextType--
+		def.symbols[rule.Name] = typ
+		def.rules = append(def.rules, regexpRule{Rule: rule, typ: typ, re: re, atLineStart: atLineStart})
+	}
+	return def, nil
+}
+
+// Must is a helper that wraps a call to a function returning (Definition,
+// error) and panics if the error is non-nil, for use in variable
+// initialisation, e.g.:
+//
+//	var MyLexer = lexer.Must(lexer.NewRegexpLexer(lexer.Rules{...}))
+func Must(def Definition, err error) Definition {
+	if err != nil {
+		panic(err)
+	}
+	return def
+}
+
+func (d *regexpDefinition) Symbols() map[string]rune {
+	return d.symbols
+}
+
+// IsTrivia implements TriviaLexer.
+func (d *regexpDefinition) IsTrivia(typ rune) bool {
+	for i := range d.rules {
+		if d.rules[i].typ == typ {
+			return d.rules[i].Trivia
+		}
+	}
+	return false
+}
+
+func (d *regexpDefinition) Lex(r io.Reader) Lexer {
+	data, err := ioutil.ReadAll(r)
+	if err != nil {
+		Panic(Position{}, err.Error())
+	}
+	filename := ""
+	if n, ok := r.(namedReader); ok {
+		filename = n.Name()
+	}
+	return &regexpLexer{
+		def:  d,
+		data: data,
+		pos:  Position{Filename: filename, Line: 1, Column: 1},
+	}
+}
+
+// regexpLexer is a Lexer produced by a regexpDefinition. It also implements
+// LexerWithTrivia.
+type regexpLexer struct {
+	def     *regexpDefinition
+	data    []byte
+	pos     Position
+	peek    *Token
+	trivia  []Token
+	pending []Token
+}
+
+func (l *regexpLexer) Next() Token {
+	if l.peek == nil {
+		l.Peek()
+	}
+	t := l.peek
+	l.peek = nil
+	l.trivia = l.pending
+	l.pending = nil
+	return *t
+}
+
+func (l *regexpLexer) Peek() Token {
+	for l.peek == nil {
+		if len(l.data) == 0 {
+			tok := Token{Type: EOF, Value: "<<EOF>>", Pos: l.pos}
+			l.peek = &tok
+			break
+		}
+		rule, match := l.match()
+		if rule == nil {
+			Panic(l.pos, fmt.Sprintf("no lexer rule matched near %q", summarise(l.data)))
+		}
+		start := l.pos
+		l.advance(match)
+		if rule.Trivia {
+			l.pending = append(l.pending, Token{Type: rule.typ, Value: string(match), Pos: start})
+			continue
+		}
+		if rule.Skip {
+			continue
+		}
+		tok := Token{Type: rule.typ, Value: string(match), Pos: start}
+		l.peek = &tok
+	}
+	return *l.peek
+}
+
+// Trivia implements LexerWithTrivia, returning the trivia tokens consumed
+// immediately before the token last returned by Next.
+func (l *regexpLexer) Trivia() []Token {
+	return l.trivia
+}
+
+// match finds the rule producing the longest match at the current position,
+// breaking ties in favour of the earliest declared rule.
+func (l *regexpLexer) match() (*regexpRule, []byte) {
+	var best *regexpRule
+	var bestMatch []byte
+	for i := range l.def.rules {
+		rule := &l.def.rules[i]
+		if rule.atLineStart && l.pos.Column != 1 {
+			continue
+		}
+		loc := rule.re.FindIndex(l.data)
+		if loc == nil {
+			continue
+		}
+		if m := l.data[loc[0]:loc[1]]; len(m) > len(bestMatch) {
+			best = rule
+			bestMatch = m
+		}
+	}
+	return best, bestMatch
+}
+
+// advance moves pos past match, updating line and column for any newlines
+// it contains.
+func (l *regexpLexer) advance(match []byte) {
+	for i := 0; i < len(match); {
+		r, size := utf8.DecodeRune(match[i:])
+		l.pos.Offset += size
+		if r == '\n' {
+			l.pos.Line++
+			l.pos.Column = 1
+		} else {
+			l.pos.Column++
+		}
+		i += size
+	}
+	l.data = l.data[len(match):]
+}
+
+// summarise returns a short, single-line prefix of data suitable for use in
+// an error message.
+func summarise(data []byte) string {
+	const max = 16
+	s := string(data)
+	if i := strings.IndexByte(s, '\n'); i >= 0 && i < max {
+		s = s[:i]
+	}
+	if len(s) > max {
+		s = s[:max] + "..."
+	}
+	return s
+}
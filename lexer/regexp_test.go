@@ -0,0 +1,147 @@
+package lexer_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/vseledkin/participle/lexer"
+)
+
+var testRules = lexer.Rules{
+	{Name: "Comment", Pattern: `//[^\n]*`, Skip: true},
+	{Name: "Keyword", Pattern: `if`},
+	{Name: "Ident", Pattern: `[a-zA-Z_]\w*`},
+	{Name: "Number", Pattern: `\d+(\.\d+)?`},
+	{Name: "Directive", Pattern: `^#[a-zA-Z]+`},
+	{Name: "Whitespace", Pattern: `[ \t\n\r]+`, Skip: true},
+	{Name: "Punct", Pattern: `.`},
+}
+
+func mustLex(t *testing.T, input string) []lexer.Token {
+	t.Helper()
+	def, err := lexer.NewRegexpLexer(testRules)
+	if err != nil {
+		t.Fatalf("NewRegexpLexer: %s", err)
+	}
+	l := def.Lex(strings.NewReader(input))
+	var tokens []lexer.Token
+	for {
+		tok := l.Next()
+		tokens = append(tokens, tok)
+		if tok.EOF() {
+			break
+		}
+	}
+	return tokens
+}
+
+func tokenValues(tokens []lexer.Token) []string {
+	values := make([]string, 0, len(tokens))
+	for _, tok := range tokens {
+		if tok.EOF() {
+			continue
+		}
+		values = append(values, tok.Value)
+	}
+	return values
+}
+
+// TestRegexpLexerLongestMatchTieBreak verifies two things: when two rules
+// produce an equal-length match, the earlier declared rule wins ("if" is
+// Keyword, not Ident, even though both match two characters); and when one
+// rule's match is strictly longer, it wins regardless of declaration order
+// ("ifx" is Ident, not the two-character Keyword match).
+func TestRegexpLexerLongestMatchTieBreak(t *testing.T) {
+	def, err := lexer.NewRegexpLexer(testRules)
+	if err != nil {
+		t.Fatalf("NewRegexpLexer: %s", err)
+	}
+	symbols := def.Symbols()
+
+	tokens := mustLex(t, "if ifx")
+	if got, want := tokenValues(tokens), []string{"if", "ifx"}; !equalStrings(got, want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+	if tokens[0].Type != symbols["Keyword"] {
+		t.Fatalf("expected tied-length match to prefer the earlier declared rule (Keyword over Ident), got type %d", tokens[0].Type)
+	}
+	if tokens[1].Type != symbols["Ident"] {
+		t.Fatalf("expected the longer Ident match to win over the shorter Keyword match, got type %d", tokens[1].Type)
+	}
+}
+
+// TestRegexpLexerSkip verifies that Skip rules (whitespace, comments) are
+// consumed but do not appear in the token stream.
+func TestRegexpLexerSkip(t *testing.T) {
+	tokens := mustLex(t, "  foo // a comment\n  bar")
+	if got, want := tokenValues(tokens), []string{"foo", "bar"}; !equalStrings(got, want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+}
+
+// TestRegexpLexerLineStartAnchor verifies that a Pattern beginning with "^"
+// only matches at column 1, falling through to other rules elsewhere.
+func TestRegexpLexerLineStartAnchor(t *testing.T) {
+	tokens := mustLex(t, "#foo\nbar #baz")
+	def, err := lexer.NewRegexpLexer(testRules)
+	if err != nil {
+		t.Fatalf("NewRegexpLexer: %s", err)
+	}
+	symbols := def.Symbols()
+
+	if tokens[0].Type != symbols["Directive"] || tokens[0].Value != "#foo" {
+		t.Fatalf("expected leading #foo to lex as a Directive, got %+v", tokens[0])
+	}
+	// The second "#" is not at the start of a line, so Directive can't match
+	// and it falls through to the single-character Punct rule instead.
+	found := false
+	for _, tok := range tokens {
+		if tok.Value == "#" && tok.Type == symbols["Punct"] {
+			found = true
+		}
+	}
+	if !found {
+		t.Fatalf("expected non-line-start '#' to lex as Punct, got %v", tokenValues(tokens))
+	}
+}
+
+// TestRegexpLexerMultilinePosition verifies that Position.Line/Column are
+// tracked correctly across newlines.
+func TestRegexpLexerMultilinePosition(t *testing.T) {
+	tokens := mustLex(t, "foo\nbar  baz")
+	want := []struct {
+		value  string
+		line   int
+		column int
+	}{
+		{"foo", 1, 1},
+		{"bar", 2, 1},
+		{"baz", 2, 6},
+	}
+	var got []lexer.Token
+	for _, tok := range tokens {
+		if !tok.EOF() {
+			got = append(got, tok)
+		}
+	}
+	if len(got) != len(want) {
+		t.Fatalf("got %d tokens, want %d: %+v", len(got), len(want), got)
+	}
+	for i, w := range want {
+		if got[i].Value != w.value || got[i].Pos.Line != w.line || got[i].Pos.Column != w.column {
+			t.Fatalf("token %d: got %+v, want {%s %d %d}", i, got[i], w.value, w.line, w.column)
+		}
+	}
+}
+
+func equalStrings(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
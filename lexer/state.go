@@ -0,0 +1,175 @@
+package lexer
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"strings"
+	"unicode/utf8"
+)
+
+// StateFn is a lexer state, in the style popularised by Rob Pike's "Lexical
+// Scanning in Go" talk and used internally by text/template. A StateFn
+// inspects input via its *L and returns the next state to transition to, or
+// nil once lexing is complete.
+type StateFn func(*L) StateFn
+
+// L holds the state threaded through a chain of StateFns. It provides the
+// primitives needed to hand-write lexers for constructs the default and
+// regexp lexers cannot express, such as indentation-sensitive blocks or
+// nested string interpolation.
+type L struct {
+	input    string
+	filename string
+	start    int // start offset of the token being scanned
+	pos      int // current scan offset
+	width    int // width of the last rune returned by Next, for Backup
+
+	line, col           int // line/column at pos
+	startLine, startCol int // line/column at start
+	prevLine, prevCol   int // line/column before the last Next, for Backup
+
+	tokens []Token
+}
+
+// Next consumes and returns the next rune of input, or EOF.
+func (l *L) Next() rune {
+	if l.pos >= len(l.input) {
+		l.width = 0
+		return EOF
+	}
+	r, w := utf8.DecodeRuneInString(l.input[l.pos:])
+	l.prevLine, l.prevCol = l.line, l.col
+	l.width = w
+	l.pos += w
+	if r == '\n' {
+		l.line++
+		l.col = 1
+	} else {
+		l.col++
+	}
+	return r
+}
+
+// Peek returns but does not consume the next rune of input.
+func (l *L) Peek() rune {
+	r := l.Next()
+	if r != EOF {
+		l.Backup()
+	}
+	return r
+}
+
+// Backup steps back one rune. It may only be called once per call to Next.
+func (l *L) Backup() {
+	if l.width == 0 {
+		// Next hit EOF and didn't consume a rune (width 0, and prevLine/prevCol
+		// weren't updated to match), so there's nothing to undo.
+		return
+	}
+	l.pos -= l.width
+	l.line, l.col = l.prevLine, l.prevCol
+}
+
+// Accept consumes the next rune if it is contained in valid, returning true
+// if it did.
+func (l *L) Accept(valid string) bool {
+	if strings.ContainsRune(valid, l.Next()) {
+		return true
+	}
+	l.Backup()
+	return false
+}
+
+// AcceptRun consumes a run of runes contained in valid.
+func (l *L) AcceptRun(valid string) {
+	for strings.ContainsRune(valid, l.Next()) {
+	}
+	l.Backup()
+}
+
+// Ignore discards the input accumulated since the last Emit or Ignore, e.g.
+// for whitespace between tokens.
+func (l *L) Ignore() {
+	l.start = l.pos
+	l.startLine, l.startCol = l.line, l.col
+}
+
+// Emit passes a token of the given type to the output, using the input
+// accumulated since the last Emit or Ignore as its value.
+func (l *L) Emit(typ rune) {
+	l.tokens = append(l.tokens, Token{
+		Type:  typ,
+		Value: l.input[l.start:l.pos],
+		Pos:   Position{Filename: l.filename, Offset: l.start, Line: l.startLine, Column: l.startCol},
+	})
+	l.start = l.pos
+	l.startLine, l.startCol = l.line, l.col
+}
+
+// Errorf raises a lexing error at the current position via Panic, and
+// returns a nil StateFn so it can be used as `return l.Errorf(...)`.
+func (l *L) Errorf(format string, args ...interface{}) StateFn {
+	Panic(Position{Filename: l.filename, Offset: l.pos, Line: l.line, Column: l.col}, fmt.Sprintf(format, args...))
+	return nil
+}
+
+// Stateful builds a Definition from a starting StateFn and a symbol table.
+// The state machine is run eagerly over the whole input when the Definition
+// is Lexed, buffering the emitted tokens so they can be replayed through
+// Peek and Next.
+func Stateful(start StateFn, symbols map[string]rune) Definition {
+	return &statefulDefinition{start: start, symbols: symbols}
+}
+
+type statefulDefinition struct {
+	start   StateFn
+	symbols map[string]rune
+}
+
+func (s *statefulDefinition) Symbols() map[string]rune {
+	out := make(map[string]rune, len(s.symbols)+1)
+	for k, v := range s.symbols {
+		out[k] = v
+	}
+	out["EOF"] = EOF
+	return out
+}
+
+func (s *statefulDefinition) Lex(r io.Reader) Lexer {
+	data, err := ioutil.ReadAll(r)
+	if err != nil {
+		Panic(Position{}, err.Error())
+	}
+	filename := ""
+	if n, ok := r.(namedReader); ok {
+		filename = n.Name()
+	}
+	l := &L{input: string(data), filename: filename, line: 1, col: 1, startLine: 1, startCol: 1}
+	for state := s.start; state != nil; {
+		state = state(l)
+	}
+	return &stateLexer{l: l}
+}
+
+// stateLexer adapts the token slice buffered by running a StateFn chain to
+// the Lexer interface.
+type stateLexer struct {
+	l   *L
+	pos int
+}
+
+func (s *stateLexer) Peek() Token {
+	if s.pos >= len(s.l.tokens) {
+		return Token{Type: EOF, Value: "<<EOF>>", Pos: Position{Filename: s.l.filename}}
+	}
+	return s.l.tokens[s.pos]
+}
+
+func (s *stateLexer) Next() Token {
+	t := s.Peek()
+	if s.pos < len(s.l.tokens) {
+		s.pos++
+	}
+	return t
+}
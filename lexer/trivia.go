@@ -0,0 +1,34 @@
+package lexer
+
+// TriviaLexer is a Definition that additionally classifies some of its token
+// types as trivia: comments, whitespace, and other tokens that are useful to
+// preserve but should not normally appear in the grammar.
+//
+// Lexers produced from a TriviaLexer elide trivia tokens from the regular
+// Peek/Next stream, as if they were Skip-ped, but still make them available
+// through the LexerWithTrivia interface. This lets tools that need them -
+// doc-comment extraction, formatters, LSPs - recover comments without
+// forcing every grammar to mention them explicitly, in the same spirit as
+// the descComment accumulation used by the neelance GraphQL lexer to attach
+// leading "#" comments to the definition that follows them.
+//
+// Wiring a @@Comment struct tag through to this is the job of the grammar
+// builder in the top-level participle package, which this lexer-only
+// checkout does not include; TriviaLexer and LexerWithTrivia are the
+// plumbing such a builder would consume.
+type TriviaLexer interface {
+	Definition
+	// IsTrivia reports whether tokens of the given type are trivia rather
+	// than regular grammar tokens.
+	IsTrivia(typ rune) bool
+}
+
+// LexerWithTrivia is implemented by Lexers produced from a TriviaLexer
+// Definition.
+type LexerWithTrivia interface {
+	Lexer
+	// Trivia returns the trivia tokens consumed immediately before the most
+	// recently returned token, in source order. It is reset on every call
+	// to Next.
+	Trivia() []Token
+}